@@ -0,0 +1,202 @@
+package api
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/valyala/fasthttp"
+)
+
+const (
+	orderKind      = "order"
+	marketDataKind = "market"
+	maxHTTPRetries = 3
+)
+
+// RateLimitConfig : the per-exchange request budget an HTTPClient enforces
+type RateLimitConfig struct {
+	OrderRPS      float64
+	MarketDataRPS float64
+}
+
+var httpMetrics = struct {
+	requests *prometheus.CounterVec
+	retries  *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+}{
+	requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "samaritan_http_requests_total",
+		Help: "Total exchange HTTP requests, by exchange/kind/status.",
+	}, []string{"exchange", "kind", "status"}),
+	retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "samaritan_http_retries_total",
+		Help: "Total exchange HTTP retries, by exchange/kind.",
+	}, []string{"exchange", "kind"}),
+	duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "samaritan_http_request_duration_seconds",
+		Help: "Exchange HTTP request latency, by exchange/kind.",
+	}, []string{"exchange", "kind"}),
+}
+
+func init() {
+	prometheus.MustRegister(httpMetrics.requests, httpMetrics.retries, httpMetrics.duration)
+}
+
+// HTTPClient : a rate-limited, retrying HTTP client for exchange REST calls, so a burst of
+// ticker/account/order calls within one strategy tick can't silently trip an exchange's
+// per-IP rate limit
+type HTTPClient struct {
+	exchange      string
+	orderLimiter  *rateLimiter
+	marketLimiter *rateLimiter
+	client        *http.Client
+	fastClient    *fasthttp.Client
+	useFasthttp   bool
+}
+
+// NewHTTPClient : create an HTTPClient for exchange, throttled to cfg.OrderRPS/MarketDataRPS.
+// The backend is net/http unless the SAMARITAN_HTTP_BACKEND environment variable is set to
+// "fasthttp".
+func NewHTTPClient(exchange string, cfg RateLimitConfig) *HTTPClient {
+	return &HTTPClient{
+		exchange:      exchange,
+		orderLimiter:  newRateLimiter(cfg.OrderRPS),
+		marketLimiter: newRateLimiter(cfg.MarketDataRPS),
+		client:        &http.Client{Timeout: 10 * time.Second},
+		fastClient:    &fasthttp.Client{},
+		useFasthttp:   strings.EqualFold(os.Getenv("SAMARITAN_HTTP_BACKEND"), "fasthttp"),
+	}
+}
+
+func (c *HTTPClient) limiterFor(kind string) *rateLimiter {
+	if kind == orderKind {
+		return c.orderLimiter
+	}
+	return c.marketLimiter
+}
+
+// Get : rate-limited, retrying GET of url, tagged with kind ("order" or "market") for metrics
+// and rate limiting
+func (c *HTTPClient) Get(kind, url string) ([]byte, error) {
+	return c.do(kind, func() ([]byte, int, error) {
+		return c.rawGet(url)
+	})
+}
+
+// PostForm : rate-limited, retrying POST of url with params as a urlencoded form body, tagged
+// with kind ("order" or "market") for metrics and rate limiting
+func (c *HTTPClient) PostForm(kind, requestURL string, params []string) ([]byte, error) {
+	return c.do(kind, func() ([]byte, int, error) {
+		return c.rawPostForm(requestURL, params)
+	})
+}
+
+func (c *HTTPClient) do(kind string, request func() ([]byte, int, error)) (body []byte, err error) {
+	limiter := c.limiterFor(kind)
+	backoff := 500 * time.Millisecond
+	var status int
+	for attempt := 0; attempt <= maxHTTPRetries; attempt++ {
+		limiter.Wait()
+		start := time.Now()
+		body, status, err = request()
+		httpMetrics.duration.WithLabelValues(c.exchange, kind).Observe(time.Since(start).Seconds())
+		httpMetrics.requests.WithLabelValues(c.exchange, kind, statusLabel(status, err)).Inc()
+		if err == nil && status != 429 && status < 500 {
+			return body, nil
+		}
+		if attempt == maxHTTPRetries {
+			break
+		}
+		httpMetrics.retries.WithLabelValues(c.exchange, kind).Inc()
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+	if err == nil {
+		err = httpStatusError{status: status}
+	}
+	return body, err
+}
+
+func statusLabel(status int, err error) string {
+	if err != nil && status == 0 {
+		return "error"
+	}
+	return fmt.Sprint(status)
+}
+
+func (c *HTTPClient) rawGet(requestURL string) ([]byte, int, error) {
+	if c.useFasthttp {
+		req := fasthttp.AcquireRequest()
+		resp := fasthttp.AcquireResponse()
+		defer fasthttp.ReleaseRequest(req)
+		defer fasthttp.ReleaseResponse(resp)
+		req.SetRequestURI(requestURL)
+		if err := c.fastClient.Do(req, resp); err != nil {
+			return nil, 0, err
+		}
+		body := append([]byte{}, resp.Body()...)
+		return body, resp.StatusCode(), nil
+	}
+	resp, err := c.client.Get(requestURL)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	return body, resp.StatusCode, err
+}
+
+func (c *HTTPClient) rawPostForm(requestURL string, params []string) ([]byte, int, error) {
+	form := url.Values{}
+	for _, param := range params {
+		kv := strings.SplitN(param, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		form.Set(kv[0], kv[1])
+	}
+	if c.useFasthttp {
+		req := fasthttp.AcquireRequest()
+		resp := fasthttp.AcquireResponse()
+		defer fasthttp.ReleaseRequest(req)
+		defer fasthttp.ReleaseResponse(resp)
+		req.SetRequestURI(requestURL)
+		req.Header.SetMethod("POST")
+		req.Header.SetContentType("application/x-www-form-urlencoded")
+		req.SetBodyString(form.Encode())
+		if err := c.fastClient.Do(req, resp); err != nil {
+			return nil, 0, err
+		}
+		body := append([]byte{}, resp.Body()...)
+		return body, resp.StatusCode(), nil
+	}
+	resp, err := c.client.PostForm(requestURL, form)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	return body, resp.StatusCode, err
+}
+
+// ServeMetrics : start a Prometheus /metrics endpoint on addr (e.g. ":9090") in the background
+func ServeMetrics(addr string) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	go http.ListenAndServe(addr, mux)
+}
+
+type httpStatusError struct {
+	status int
+}
+
+func (e httpStatusError) Error() string {
+	return fmt.Sprintf("unexpected HTTP status %v", e.status)
+}
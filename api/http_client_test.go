@@ -0,0 +1,51 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHTTPClientRetriesOn429ThenSucceeds(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	c := NewHTTPClient("test", RateLimitConfig{OrderRPS: 0, MarketDataRPS: 0})
+	body, err := c.Get(marketDataKind, server.URL)
+	if err != nil {
+		t.Fatalf("Get() error: %v", err)
+	}
+	if string(body) != "ok" {
+		t.Fatalf("Get() body = %q, want %q", body, "ok")
+	}
+	if requests != 2 {
+		t.Fatalf("server received %v requests, want 2 (one 429, then a retry that succeeds)", requests)
+	}
+}
+
+func TestHTTPClientGivesUpAfterMaxRetries(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	c := NewHTTPClient("test", RateLimitConfig{OrderRPS: 0, MarketDataRPS: 0})
+	_, err := c.Get(marketDataKind, server.URL)
+	if err == nil {
+		t.Fatal("Get() error = nil, want an error after exhausting retries on a persistent 429")
+	}
+	if requests != maxHTTPRetries+1 {
+		t.Fatalf("server received %v requests, want %v (the initial attempt plus maxHTTPRetries retries)", requests, maxHTTPRetries+1)
+	}
+}
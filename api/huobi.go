@@ -13,13 +13,18 @@ import (
 
 // Huobi : the exchange struct of okcoin.cn
 type Huobi struct {
-	stockMap     map[string]string
-	orderTypeMap map[string]int
-	periodMap    map[string]string
-	records      map[string][]Record
-	host         string
-	log          log.Logger
-	option       Option
+	stockMap      map[string]string
+	orderTypeMap  map[string]int
+	periodMap     map[string]string
+	instrumentMap map[string]InstrumentInfo
+	records       map[string][]Record
+	host          string
+	log           log.Logger
+	option        Option
+	store         Store
+	sessionID     string
+	httpClient    *HTTPClient
+	streamState
 }
 
 // NewHuobi : create an exchange struct of okcoin.cn
@@ -28,10 +33,26 @@ func NewHuobi(opt Option) *Huobi {
 		stockMap:     map[string]string{"BTC": "1", "LTC": "2"},
 		orderTypeMap: map[string]int{"1": 1, "2": -1, "3": 2, "4": -2},
 		periodMap:    map[string]string{"M": "001", "M5": "005", "M15": "015", "M30": "030", "H": "060", "D": "100", "W": "200"},
-		records:      make(map[string][]Record),
-		host:         "https://api.huobi.com/apiv3",
-		log:          log.New(opt.Type),
-		option:       opt,
+		instrumentMap: map[string]InstrumentInfo{
+			"BTC": {Symbol: "BTC", PriceTickSize: 0.01, AmountTickSize: 0.0001, MinNotional: 1},
+			"LTC": {Symbol: "LTC", PriceTickSize: 0.01, AmountTickSize: 0.001, MinNotional: 1},
+		},
+		records:   make(map[string][]Record),
+		host:      "https://api.huobi.com/apiv3",
+		log:       log.New(opt.Type),
+		option:    opt,
+		sessionID: fmt.Sprint(time.Now().UnixNano()),
+		httpClient: NewHTTPClient(huobiExchangeTag, RateLimitConfig{
+			OrderRPS:      10,
+			MarketDataRPS: 10,
+		}),
+		streamState: streamState{
+			wsTopics:    make(map[string]bool),
+			tickerCache: make(map[string]Ticker),
+			depthSubs:   make(map[string][]chan Ticker),
+			tradeSubs:   make(map[string][]chan Trade),
+			klineSubs:   make(map[string]map[string][]chan Record),
+		},
 	}
 	if _, ok := e.stockMap[e.option.MainStock]; !ok {
 		e.option.MainStock = "BTC"
@@ -57,6 +78,18 @@ func (e *Huobi) SetMainStock(stock string) string {
 	return e.option.MainStock
 }
 
+// GetInstrumentInfo : get the tick sizes and trading limits of a stock type, so strategies can
+// round prices/amounts or size orders without tripping the exchange's precision rules
+func (e *Huobi) GetInstrumentInfo(stockType string) InstrumentInfo {
+	return e.instrumentMap[stockType]
+}
+
+// ServeMetrics : start a Prometheus /metrics endpoint on addr (e.g. ":9090") exposing this
+// exchange's HTTPClient request counts, retries and latencies
+func (e *Huobi) ServeMetrics(addr string) {
+	ServeMetrics(addr)
+}
+
 func (e *Huobi) getAuthJSON(url string, params []string, optionals ...string) (json *simplejson.Json, err error) {
 	params = append(params, []string{
 		"access_key=" + e.option.AccessKey,
@@ -65,13 +98,49 @@ func (e *Huobi) getAuthJSON(url string, params []string, optionals ...string) (j
 	}...)
 	sort.Strings(params)
 	params = append(params, "sign="+signMd5(params))
-	resp, err := post(url, append(params, optionals...))
+	resp, err := e.httpClient.PostForm(orderKind, url, append(params, optionals...))
 	if err != nil {
 		return
 	}
 	return simplejson.NewJson(resp)
 }
 
+// buildMethodParam translates price and a set of LimitOrderOption flags into the Huobi
+// `method=` parameter for the given side ("buy" or "sell"), rejecting combinations that
+// Huobi has no advanced order type for.
+func (e *Huobi) buildMethodParam(side string, price float64, opts []LimitOrderOption) (string, error) {
+	if price <= 0 {
+		if len(opts) > 0 {
+			return "", fmt.Errorf("buildMethodParam() error, LimitOrderOption is not supported on market orders")
+		}
+		return "method=" + side + "_market", nil
+	}
+	postOnly, ioc, fok := false, false, false
+	for _, opt := range opts {
+		switch opt {
+		case PostOnly:
+			postOnly = true
+		case IOC:
+			ioc = true
+		case FOK:
+			fok = true
+		default:
+			return "", fmt.Errorf("buildMethodParam() error, unrecognized LimitOrderOption: %v", opt)
+		}
+	}
+	switch {
+	case postOnly && (ioc || fok), ioc && fok:
+		return "", fmt.Errorf("buildMethodParam() error, PostOnly, IOC and FOK are mutually exclusive")
+	case postOnly:
+		return "method=" + side + "_maker", nil
+	case ioc:
+		return "method=" + side + "_ioc", nil
+	case fok:
+		return "method=" + side + "_fok", nil
+	}
+	return "method=" + side, nil
+}
+
 // GetAccount : get the account detail of this exchange
 func (e *Huobi) GetAccount() interface{} {
 	params := []string{
@@ -105,6 +174,7 @@ func (e *Huobi) GetAccount() interface{} {
 		account.Stock = account.LTC
 		account.FrozenStock = account.FrozenLTC
 	}
+	e.persistAccount(account)
 	return account
 }
 
@@ -114,13 +184,20 @@ func (e *Huobi) Buy(stockType string, price, amount float64, msgs ...interface{}
 		e.log.Do("error", 0.0, 0.0, "Buy() error, unrecognized stockType: ", stockType)
 		return
 	}
+	opts, msgs := extractLimitOrderOptions(msgs)
+	methodParam, err := e.buildMethodParam("buy", price, opts)
+	if err != nil {
+		e.log.Do("error", 0.0, 0.0, "Buy() error, ", err)
+		return
+	}
+	instrument := e.GetInstrumentInfo(stockType)
+	amount = RoundToTick(amount, instrument.AmountTickSize, RoundDown)
 	params := []string{
 		"coin_type=" + e.stockMap[stockType],
 		fmt.Sprint("amount=", amount),
 	}
-	methodParam := "method=buy_market"
 	if price > 0 {
-		methodParam = "method=buy"
+		price = RoundToTick(price, instrument.PriceTickSize, RoundNearest)
 		params = append(params, fmt.Sprint("price=", price))
 	}
 	params = append(params, methodParam)
@@ -136,6 +213,11 @@ func (e *Huobi) Buy(stockType string, price, amount float64, msgs ...interface{}
 	}
 	e.log.Do("buy", price, amount, msgs...)
 	id = fmt.Sprint(json.Get("id").Interface())
+	orderType := 2
+	if price > 0 {
+		orderType = 1
+	}
+	e.persistOrder(Order{ID: id, Price: price, Amount: amount, OrderType: orderType, StockType: stockType}, OrderPlaced)
 	return
 }
 
@@ -145,13 +227,20 @@ func (e *Huobi) Sell(stockType string, price, amount float64, msgs ...interface{
 		e.log.Do("error", 0.0, 0.0, "Sell() error, unrecognized stockType: ", stockType)
 		return
 	}
+	opts, msgs := extractLimitOrderOptions(msgs)
+	methodParam, err := e.buildMethodParam("sell", price, opts)
+	if err != nil {
+		e.log.Do("error", 0.0, 0.0, "Sell() error, ", err)
+		return
+	}
+	instrument := e.GetInstrumentInfo(stockType)
+	amount = RoundToTick(amount, instrument.AmountTickSize, RoundDown)
 	params := []string{
 		"coin_type=" + e.stockMap[stockType],
 		fmt.Sprint("amount=", amount),
 	}
-	methodParam := "method=sell_market"
 	if price > 0 {
-		methodParam = "method=sell"
+		price = RoundToTick(price, instrument.PriceTickSize, RoundNearest)
 		params = append(params, fmt.Sprint("price=", price))
 	}
 	params = append(params, methodParam)
@@ -167,6 +256,11 @@ func (e *Huobi) Sell(stockType string, price, amount float64, msgs ...interface{
 	}
 	e.log.Do("sell", price, amount, msgs...)
 	id = fmt.Sprint(json.Get("id").Interface())
+	orderType := -2
+	if price > 0 {
+		orderType = -1
+	}
+	e.persistOrder(Order{ID: id, Price: price, Amount: amount, OrderType: orderType, StockType: stockType}, OrderPlaced)
 	return
 }
 
@@ -216,6 +310,7 @@ func (e *Huobi) CancelOrder(order Order) bool {
 	}
 	if json.Get("result").MustString() == "success" {
 		e.log.Do("cancel", 0.0, 0.0, fmt.Sprintf("%+v", order))
+		e.persistOrder(order, OrderCancelled)
 		return true
 	}
 	e.log.Do("error", 0.0, 0.0, "CancelOrder() error, ", json.Get("msg").Interface())
@@ -298,12 +393,20 @@ func (e *Huobi) GetTicker(stockType string, sizes ...int) interface{} {
 		e.log.Do("error", 0.0, 0.0, "GetTicker() error, unrecognized stockType: ", stockType)
 		return nil
 	}
+	if e.isSubscribed(depthTopic(stockType)) {
+		e.tickerCacheMu.RLock()
+		cached, ok := e.tickerCache[stockType]
+		e.tickerCacheMu.RUnlock()
+		if ok {
+			return cached
+		}
+	}
 	size := 20
 	if len(sizes) > 0 && sizes[0] > 20 {
 		size = sizes[0]
 	}
 
-	resp, err := get(fmt.Sprint("http://api.huobi.com/staticmarket/depth_", strings.ToLower(stockType), "_", size, ".js"))
+	resp, err := e.httpClient.Get(marketDataKind, fmt.Sprint("http://api.huobi.com/staticmarket/depth_", strings.ToLower(stockType), "_", size, ".js"))
 	if err != nil {
 		e.log.Do("error", 0.0, 0.0, "GetTicker() error, ", err)
 		return nil
@@ -337,6 +440,9 @@ func (e *Huobi) GetTicker(stockType string, sizes ...int) interface{} {
 	ticker.Buy = ticker.Bids[0].Price
 	ticker.Sell = ticker.Asks[0].Price
 	ticker.Mid = (ticker.Buy + ticker.Sell) / 2
+	e.tickerCacheMu.Lock()
+	e.tickerCache[stockType] = ticker
+	e.tickerCacheMu.Unlock()
 	return ticker
 }
 
@@ -354,7 +460,19 @@ func (e *Huobi) GetRecords(stockType, period string, sizes ...int) (records []Re
 	if len(sizes) > 0 {
 		size = sizes[0]
 	}
-	resp, err := get(fmt.Sprint("http://api.huobi.com/staticmarket/", strings.ToLower(stockType), "_kline_", e.periodMap[period], "_json.js"))
+	key := recordKey(stockType, period)
+	if e.isSubscribed(klineTopic(stockType, period)) {
+		e.recordCacheMu.Lock()
+		cached := e.records[key]
+		e.recordCacheMu.Unlock()
+		if len(cached) > 0 {
+			if len(cached) > size {
+				cached = cached[len(cached)-size:]
+			}
+			return cached
+		}
+	}
+	resp, err := e.httpClient.Get(marketDataKind, fmt.Sprint("http://api.huobi.com/staticmarket/", strings.ToLower(stockType), "_kline_", e.periodMap[period], "_json.js"))
 	if err != nil {
 		e.log.Do("error", 0.0, 0.0, "GetRecords() error, ", err)
 		return
@@ -364,9 +482,11 @@ func (e *Huobi) GetRecords(stockType, period string, sizes ...int) (records []Re
 		e.log.Do("error", 0.0, 0.0, "GetRecords() error, ", err)
 		return
 	}
+	e.recordCacheMu.Lock()
+	defer e.recordCacheMu.Unlock()
 	timeLast := int64(0)
-	if len(e.records[period]) > 0 {
-		timeLast = e.records[period][len(e.records[period])-1].Time
+	if len(e.records[key]) > 0 {
+		timeLast = e.records[key][len(e.records[key])-1].Time
 	}
 	recordsNew := []Record{}
 	for i := len(json.MustArray()); i > 0; i-- {
@@ -382,7 +502,7 @@ func (e *Huobi) GetRecords(stockType, period string, sizes ...int) (records []Re
 				Volume: recordJSON.GetIndex(5).MustFloat64(),
 			})
 		} else if recordTime == timeLast {
-			e.records[period][len(e.records[period])-1] = Record{
+			e.records[key][len(e.records[key])-1] = Record{
 				Time:   recordTime,
 				Open:   recordJSON.GetIndex(1).MustFloat64(),
 				High:   recordJSON.GetIndex(2).MustFloat64(),
@@ -394,10 +514,9 @@ func (e *Huobi) GetRecords(stockType, period string, sizes ...int) (records []Re
 			break
 		}
 	}
-	e.records[period] = append(e.records[period], recordsNew...)
-	if len(e.records[period]) > size {
-		e.records[period] = e.records[period][:size]
+	e.records[key] = append(e.records[key], recordsNew...)
+	if len(e.records[key]) > size {
+		e.records[key] = e.records[key][:size]
 	}
-	fmt.Println(len(e.records[period]))
-	return e.records[period]
-}
\ No newline at end of file
+	return e.records[key]
+}
@@ -0,0 +1,54 @@
+package api
+
+import "time"
+
+const huobiExchangeTag = "huobi"
+
+// SetStore : persist every successful Buy/Sell/CancelOrder and GetAccount result to store,
+// tagged with this Huobi instance's session id
+func (e *Huobi) SetStore(store Store) {
+	e.store = store
+}
+
+// Resume : adopt a previous run's sessionID so persistence continues under the same identity,
+// then replay that session's trade log to recover the orders it last knew were still open.
+// Callers should confirm each returned Order with GetOrder before acting on it, since the log
+// can't see fills that happened on the exchange after the crash.
+func (e *Huobi) Resume(store Store, sessionID string) ([]Order, error) {
+	e.store = store
+	e.sessionID = sessionID
+	records, err := store.QueryTrades(sessionID, huobiExchangeTag, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	return OpenOrdersFromTrades(records), nil
+}
+
+func (e *Huobi) persistOrder(order Order, event OrderEvent) {
+	if e.store == nil {
+		return
+	}
+	if err := e.store.SaveOrder(TradeRecord{
+		SessionID: e.sessionID,
+		Exchange:  huobiExchangeTag,
+		Time:      time.Now(),
+		Event:     event,
+		Order:     order,
+	}); err != nil {
+		e.log.Do("error", 0.0, 0.0, "persistOrder() error, ", err)
+	}
+}
+
+func (e *Huobi) persistAccount(account Account) {
+	if e.store == nil {
+		return
+	}
+	if err := e.store.SaveAccount(AccountSnapshot{
+		SessionID: e.sessionID,
+		Exchange:  huobiExchangeTag,
+		Time:      time.Now(),
+		Account:   account,
+	}); err != nil {
+		e.log.Do("error", 0.0, 0.0, "persistAccount() error, ", err)
+	}
+}
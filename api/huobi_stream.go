@@ -0,0 +1,332 @@
+package api
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bitly/go-simplejson"
+	"github.com/gorilla/websocket"
+)
+
+const wsHost = "wss://api.huobi.com/ws"
+
+// GzipDecompress : inflate a gzip-compressed websocket frame, as Huobi sends every message gzipped
+func GzipDecompress(data []byte) ([]byte, error) {
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return ioutil.ReadAll(reader)
+}
+
+// depthTopic, tradeTopic and klineTopic name the Huobi websocket channel for a given
+// stockType/period, shared by the Subscribe* methods and by GetTicker/GetRecords so they can
+// tell whether a stream is already keeping their cache fresh.
+func depthTopic(stockType string) string {
+	return fmt.Sprintf("market.%v_cny.depth.step0", strings.ToLower(stockType))
+}
+
+func tradeTopic(stockType string) string {
+	return fmt.Sprintf("market.%v_cny.trade.detail", strings.ToLower(stockType))
+}
+
+func klineTopic(stockType, period string) string {
+	return fmt.Sprintf("market.%v_cny.kline.%v", strings.ToLower(stockType), period)
+}
+
+// recordKey keys e.records by stockType+period, so two stockTypes subscribed to the same
+// period (e.g. SubscribeKline("BTC","M") and SubscribeKline("LTC","M")) never share a slot.
+func recordKey(stockType, period string) string {
+	return stockType + "|" + period
+}
+
+// isSubscribed reports whether topic has an active (or reconnect-pending) subscription
+func (e *Huobi) isSubscribed(topic string) bool {
+	e.subMu.Lock()
+	defer e.subMu.Unlock()
+	return e.wsTopics[topic]
+}
+
+// SubscribeDepth : stream realtime order book updates for a stockType
+func (e *Huobi) SubscribeDepth(stockType string) (<-chan Ticker, error) {
+	if _, ok := e.stockMap[stockType]; !ok {
+		return nil, fmt.Errorf("SubscribeDepth() error, unrecognized stockType: %v", stockType)
+	}
+	ch := make(chan Ticker, 32)
+	e.subMu.Lock()
+	e.depthSubs[stockType] = append(e.depthSubs[stockType], ch)
+	e.subMu.Unlock()
+	e.ensureStream()
+	e.subscribe(depthTopic(stockType))
+	return ch, nil
+}
+
+// SubscribeTrades : stream realtime trade prints for a stockType
+func (e *Huobi) SubscribeTrades(stockType string) (<-chan Trade, error) {
+	if _, ok := e.stockMap[stockType]; !ok {
+		return nil, fmt.Errorf("SubscribeTrades() error, unrecognized stockType: %v", stockType)
+	}
+	ch := make(chan Trade, 32)
+	e.subMu.Lock()
+	e.tradeSubs[stockType] = append(e.tradeSubs[stockType], ch)
+	e.subMu.Unlock()
+	e.ensureStream()
+	e.subscribe(tradeTopic(stockType))
+	return ch, nil
+}
+
+// SubscribeKline : stream realtime candlestick updates for a stockType/period
+func (e *Huobi) SubscribeKline(stockType, period string) (<-chan Record, error) {
+	if _, ok := e.stockMap[stockType]; !ok {
+		return nil, fmt.Errorf("SubscribeKline() error, unrecognized stockType: %v", stockType)
+	}
+	if _, ok := e.periodMap[period]; !ok {
+		return nil, fmt.Errorf("SubscribeKline() error, unrecognized period: %v", period)
+	}
+	ch := make(chan Record, 32)
+	e.subMu.Lock()
+	if e.klineSubs[stockType] == nil {
+		e.klineSubs[stockType] = make(map[string][]chan Record)
+	}
+	e.klineSubs[stockType][period] = append(e.klineSubs[stockType][period], ch)
+	e.subMu.Unlock()
+	e.ensureStream()
+	e.subscribe(klineTopic(stockType, period))
+	return ch, nil
+}
+
+// ensureStream lazily dials the Huobi websocket feed and starts the read/reconnect loop once.
+func (e *Huobi) ensureStream() {
+	e.wsMu.Lock()
+	defer e.wsMu.Unlock()
+	if e.wsStarted {
+		return
+	}
+	e.wsStarted = true
+	go e.streamLoop()
+}
+
+// subscribe re-sends a subscription request for topic, replaying it after every reconnect.
+func (e *Huobi) subscribe(topic string) {
+	e.subMu.Lock()
+	e.wsTopics[topic] = true
+	e.subMu.Unlock()
+	e.sendSubscribe(topic)
+}
+
+func (e *Huobi) sendSubscribe(topic string) {
+	e.wsMu.Lock()
+	conn := e.wsConn
+	e.wsMu.Unlock()
+	if conn == nil {
+		return
+	}
+	conn.WriteJSON(map[string]interface{}{"sub": topic, "id": topic})
+}
+
+// streamLoop keeps a websocket connection to Huobi alive, reconnecting with exponential backoff
+// on any read/dial error, and dispatches every decoded message to handleStreamMessage.
+func (e *Huobi) streamLoop() {
+	backoff := time.Second
+	const maxBackoff = time.Minute
+	for {
+		conn, _, err := websocket.DefaultDialer.Dial(wsHost, nil)
+		if err != nil {
+			e.log.Do("error", 0.0, 0.0, "streamLoop() error, ", err)
+			time.Sleep(backoff)
+			if backoff < maxBackoff {
+				backoff *= 2
+			}
+			continue
+		}
+		e.wsMu.Lock()
+		e.wsConn = conn
+		e.wsMu.Unlock()
+		backoff = time.Second
+
+		e.subMu.Lock()
+		topics := make([]string, 0, len(e.wsTopics))
+		for topic := range e.wsTopics {
+			topics = append(topics, topic)
+		}
+		e.subMu.Unlock()
+		for _, topic := range topics {
+			e.sendSubscribe(topic)
+		}
+
+		for {
+			_, raw, err := conn.ReadMessage()
+			if err != nil {
+				e.log.Do("error", 0.0, 0.0, "streamLoop() error, ", err)
+				break
+			}
+			msg, err := GzipDecompress(raw)
+			if err != nil {
+				e.log.Do("error", 0.0, 0.0, "streamLoop() error, ", err)
+				continue
+			}
+			e.handleStreamMessage(msg)
+		}
+		conn.Close()
+		e.wsMu.Lock()
+		e.wsConn = nil
+		e.wsMu.Unlock()
+		time.Sleep(backoff)
+	}
+}
+
+// handleStreamMessage parses one decompressed frame, answers Huobi's heartbeat ping and routes
+// market data updates into the shared caches and any subscriber channels.
+func (e *Huobi) handleStreamMessage(raw []byte) {
+	json, err := simplejson.NewJson(raw)
+	if err != nil {
+		e.log.Do("error", 0.0, 0.0, "handleStreamMessage() error, ", err)
+		return
+	}
+	if ping, ok := json.CheckGet("ping"); ok {
+		e.wsMu.Lock()
+		conn := e.wsConn
+		e.wsMu.Unlock()
+		if conn != nil {
+			conn.WriteJSON(map[string]interface{}{"pong": ping.MustInt64()})
+		}
+		return
+	}
+	ch := json.Get("ch").MustString()
+	parts := strings.Split(ch, ".")
+	if len(parts) < 3 {
+		return
+	}
+	stockType := strings.ToUpper(strings.TrimSuffix(parts[1], "_cny"))
+	tick := json.Get("tick")
+	switch parts[2] {
+	case "depth":
+		e.handleDepthUpdate(stockType, tick)
+	case "trade":
+		e.handleTradeUpdate(stockType, tick)
+	case "kline":
+		if len(parts) >= 4 {
+			e.handleKlineUpdate(stockType, parts[3], tick)
+		}
+	}
+}
+
+func (e *Huobi) handleDepthUpdate(stockType string, tick *simplejson.Json) {
+	ticker := Ticker{}
+	bidsJSON := tick.Get("bids")
+	for i := 0; i < len(bidsJSON.MustArray()); i++ {
+		depthJSON := bidsJSON.GetIndex(i)
+		ticker.Bids = append(ticker.Bids, MarketOrder{
+			Price:  depthJSON.GetIndex(0).MustFloat64(),
+			Amount: depthJSON.GetIndex(1).MustFloat64(),
+		})
+	}
+	asksJSON := tick.Get("asks")
+	for i := 0; i < len(asksJSON.MustArray()); i++ {
+		depthJSON := asksJSON.GetIndex(i)
+		ticker.Asks = append(ticker.Asks, MarketOrder{
+			Price:  depthJSON.GetIndex(0).MustFloat64(),
+			Amount: depthJSON.GetIndex(1).MustFloat64(),
+		})
+	}
+	if len(ticker.Bids) < 1 || len(ticker.Asks) < 1 {
+		return
+	}
+	ticker.Buy = ticker.Bids[0].Price
+	ticker.Sell = ticker.Asks[0].Price
+	ticker.Mid = (ticker.Buy + ticker.Sell) / 2
+
+	e.tickerCacheMu.Lock()
+	e.tickerCache[stockType] = ticker
+	e.tickerCacheMu.Unlock()
+
+	e.subMu.Lock()
+	subs := e.depthSubs[stockType]
+	e.subMu.Unlock()
+	for _, ch := range subs {
+		select {
+		case ch <- ticker:
+		default:
+		}
+	}
+}
+
+func (e *Huobi) handleTradeUpdate(stockType string, tick *simplejson.Json) {
+	e.subMu.Lock()
+	subs := e.tradeSubs[stockType]
+	e.subMu.Unlock()
+	if len(subs) == 0 {
+		return
+	}
+	dataJSON := tick.Get("data")
+	for i := 0; i < len(dataJSON.MustArray()); i++ {
+		tradeJSON := dataJSON.GetIndex(i)
+		trade := Trade{
+			Price:     tradeJSON.Get("price").MustFloat64(),
+			Amount:    tradeJSON.Get("amount").MustFloat64(),
+			Direction: tradeJSON.Get("direction").MustString(),
+			Time:      tradeJSON.Get("ts").MustInt64(),
+		}
+		for _, ch := range subs {
+			select {
+			case ch <- trade:
+			default:
+			}
+		}
+	}
+}
+
+func (e *Huobi) handleKlineUpdate(stockType, period string, tick *simplejson.Json) {
+	record := Record{
+		Time:   tick.Get("id").MustInt64(),
+		Open:   tick.Get("open").MustFloat64(),
+		High:   tick.Get("high").MustFloat64(),
+		Low:    tick.Get("low").MustFloat64(),
+		Close:  tick.Get("close").MustFloat64(),
+		Volume: tick.Get("vol").MustFloat64(),
+	}
+
+	key := recordKey(stockType, period)
+	e.recordCacheMu.Lock()
+	records := e.records[key]
+	if n := len(records); n > 0 && records[n-1].Time == record.Time {
+		records[n-1] = record
+	} else {
+		records = append(records, record)
+	}
+	e.records[key] = records
+	e.recordCacheMu.Unlock()
+
+	e.subMu.Lock()
+	subs := e.klineSubs[stockType][period]
+	e.subMu.Unlock()
+	for _, ch := range subs {
+		select {
+		case ch <- record:
+		default:
+		}
+	}
+}
+
+// streamState holds everything the websocket streaming subsystem needs, kept separate from the
+// REST-only fields. NewHuobi allocates its maps up front (GetTicker also fills tickerCache from
+// REST), but no websocket is dialled until a caller actually subscribes.
+type streamState struct {
+	wsConn        *websocket.Conn
+	wsMu          sync.Mutex
+	wsStarted     bool
+	wsTopics      map[string]bool
+	tickerCache   map[string]Ticker
+	tickerCacheMu sync.RWMutex
+	recordCacheMu sync.Mutex
+	subMu         sync.Mutex
+	depthSubs     map[string][]chan Ticker
+	tradeSubs     map[string][]chan Trade
+	klineSubs     map[string]map[string][]chan Record
+}
@@ -0,0 +1,125 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/bitly/go-simplejson"
+)
+
+func klineTick(id int64, close float64) *simplejson.Json {
+	json, _ := simplejson.NewJson([]byte(`{}`))
+	json.Set("id", id)
+	json.Set("open", close)
+	json.Set("high", close)
+	json.Set("low", close)
+	json.Set("close", close)
+	json.Set("vol", 1.0)
+	return json
+}
+
+// TestHandleKlineUpdateKeyedByStockType covers the regression where two stockTypes subscribed
+// to the same period (e.g. SubscribeKline("BTC","M") and SubscribeKline("LTC","M")) shared a
+// single e.records["M"] slot and overwrote each other's candles.
+func TestHandleKlineUpdateKeyedByStockType(t *testing.T) {
+	e := NewHuobi(Option{})
+	e.subscribe(klineTopic("BTC", "M"))
+	e.subscribe(klineTopic("LTC", "M"))
+
+	e.handleKlineUpdate("BTC", "M", klineTick(1, 100))
+	e.handleKlineUpdate("LTC", "M", klineTick(1, 200))
+
+	btc := e.GetRecords("BTC", "M")
+	ltc := e.GetRecords("LTC", "M")
+
+	if len(btc) != 1 || btc[0].Close != 100 {
+		t.Fatalf("GetRecords(BTC, M) = %+v, want one record with Close=100", btc)
+	}
+	if len(ltc) != 1 || ltc[0].Close != 200 {
+		t.Fatalf("GetRecords(LTC, M) = %+v, want one record with Close=200", ltc)
+	}
+}
+
+// TestHandleStreamMessagePing covers Huobi's heartbeat: a {"ping":...} frame with no live
+// websocket connection must not panic, and must not be mistaken for market data.
+func TestHandleStreamMessagePing(t *testing.T) {
+	e := NewHuobi(Option{})
+	e.handleStreamMessage([]byte(`{"ping":1234567890}`))
+	if len(e.tickerCache) != 0 || len(e.records) != 0 {
+		t.Fatalf("ping frame updated market data caches: tickerCache=%+v records=%+v", e.tickerCache, e.records)
+	}
+}
+
+// TestHandleStreamMessageDepth feeds a canned depth frame through the full routing path
+// (handleStreamMessage -> handleDepthUpdate) and checks the ticker cache and a depth
+// subscriber channel both observe it.
+func TestHandleStreamMessageDepth(t *testing.T) {
+	e := NewHuobi(Option{})
+	ch := make(chan Ticker, 1)
+	e.subMu.Lock()
+	e.depthSubs["BTC"] = append(e.depthSubs["BTC"], ch)
+	e.subMu.Unlock()
+
+	e.handleStreamMessage([]byte(`{"ch":"market.btc_cny.depth.step0","ts":1,"tick":{"bids":[[100,1]],"asks":[[101,2]]}}`))
+
+	e.tickerCacheMu.RLock()
+	cached, ok := e.tickerCache["BTC"]
+	e.tickerCacheMu.RUnlock()
+	if !ok || cached.Buy != 100 || cached.Sell != 101 {
+		t.Fatalf("tickerCache[BTC] = %+v, ok=%v, want Buy=100 Sell=101", cached, ok)
+	}
+	select {
+	case got := <-ch:
+		if got.Buy != 100 || got.Sell != 101 {
+			t.Fatalf("depth subscriber received %+v, want Buy=100 Sell=101", got)
+		}
+	default:
+		t.Fatal("depth subscriber channel received nothing")
+	}
+}
+
+// TestHandleStreamMessageTrade feeds a canned trade frame through handleStreamMessage and
+// checks a trade subscriber channel observes it.
+func TestHandleStreamMessageTrade(t *testing.T) {
+	e := NewHuobi(Option{})
+	ch := make(chan Trade, 1)
+	e.subMu.Lock()
+	e.tradeSubs["BTC"] = append(e.tradeSubs["BTC"], ch)
+	e.subMu.Unlock()
+
+	e.handleStreamMessage([]byte(`{"ch":"market.btc_cny.trade.detail","ts":1,"tick":{"data":[{"price":100,"amount":2,"direction":"buy","ts":123}]}}`))
+
+	select {
+	case got := <-ch:
+		if got.Price != 100 || got.Amount != 2 || got.Direction != "buy" || got.Time != 123 {
+			t.Fatalf("trade subscriber received %+v, want Price=100 Amount=2 Direction=buy Time=123", got)
+		}
+	default:
+		t.Fatal("trade subscriber channel received nothing")
+	}
+}
+
+// TestHandleStreamMessageKline feeds a canned kline frame through handleStreamMessage and
+// checks it lands in e.records under the stockType+period key, and reaches a kline subscriber.
+func TestHandleStreamMessageKline(t *testing.T) {
+	e := NewHuobi(Option{})
+	ch := make(chan Record, 1)
+	e.subMu.Lock()
+	e.klineSubs["BTC"] = map[string][]chan Record{"M": {ch}}
+	e.subMu.Unlock()
+	e.subscribe(klineTopic("BTC", "M"))
+
+	e.handleStreamMessage([]byte(`{"ch":"market.btc_cny.kline.M","ts":1,"tick":{"id":1,"open":100,"high":110,"low":90,"close":105,"vol":10}}`))
+
+	records := e.GetRecords("BTC", "M")
+	if len(records) != 1 || records[0].Close != 105 {
+		t.Fatalf("GetRecords(BTC, M) = %+v, want one record with Close=105", records)
+	}
+	select {
+	case got := <-ch:
+		if got.Close != 105 {
+			t.Fatalf("kline subscriber received %+v, want Close=105", got)
+		}
+	default:
+		t.Fatal("kline subscriber channel received nothing")
+	}
+}
@@ -0,0 +1,46 @@
+package api
+
+import "math"
+
+// InstrumentInfo : the tradable precision and limits of a single market on an exchange
+type InstrumentInfo struct {
+	Symbol         string
+	PriceTickSize  float64
+	AmountTickSize float64
+	MinNotional    float64
+	ContractVal    float64
+}
+
+// RoundMode : how RoundToTick should snap a value that falls between two ticks
+type RoundMode int
+
+const (
+	// RoundDown : snap to the nearest tick below the value (never over-spend / over-sell)
+	RoundDown RoundMode = iota
+	// RoundUp : snap to the nearest tick above the value
+	RoundUp
+	// RoundNearest : snap to whichever tick is closest
+	RoundNearest
+)
+
+// roundEpsilon compensates for float64 division imprecision (e.g. 19.99/0.01 landing on
+// 1998.99999999999977 instead of 1999) so Floor/Ceil don't clip a whole extra tick.
+const roundEpsilon = 1e-9
+
+// RoundToTick : snap value to the nearest multiple of tick according to mode, returning value
+// unchanged if tick is not a positive number
+func RoundToTick(value, tick float64, mode RoundMode) float64 {
+	if tick <= 0 {
+		return value
+	}
+	steps := value / tick
+	switch mode {
+	case RoundUp:
+		steps = math.Ceil(steps - roundEpsilon)
+	case RoundNearest:
+		steps = math.Floor(steps + 0.5 + roundEpsilon)
+	default:
+		steps = math.Floor(steps + roundEpsilon)
+	}
+	return steps * tick
+}
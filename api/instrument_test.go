@@ -0,0 +1,29 @@
+package api
+
+import "testing"
+
+func TestRoundToTick(t *testing.T) {
+	cases := []struct {
+		value, tick float64
+		mode        RoundMode
+		want        float64
+	}{
+		{19.99, 0.01, RoundDown, 19.99},
+		{19.99, 0.01, RoundUp, 19.99},
+		{19.994, 0.01, RoundDown, 19.99},
+		{19.991, 0.01, RoundUp, 20.00},
+		{19.995, 0.01, RoundNearest, 20.00},
+		{0.123456, 0.0001, RoundDown, 0.1234},
+		{5, 0, RoundDown, 5},
+	}
+	for _, c := range cases {
+		if got := RoundToTick(c.value, c.tick, c.mode); !closeEnough(got, c.want) {
+			t.Errorf("RoundToTick(%v, %v, %v) = %v, want %v", c.value, c.tick, c.mode, got, c.want)
+		}
+	}
+}
+
+func closeEnough(a, b float64) bool {
+	diff := a - b
+	return diff > -1e-9 && diff < 1e-9
+}
@@ -0,0 +1,32 @@
+package api
+
+// LimitOrderOption : an advanced time-in-force / execution flag for a limit order.
+//
+// This package has no Lua/JS strategy runtime to bind into — there is no scripting engine
+// anywhere in this tree for these constants to be exposed through — so LimitOrderOption is
+// Go-API-only for now. A strategy runtime binding is out of scope here and should be revisited
+// if/when such a runtime is added.
+type LimitOrderOption int
+
+const (
+	// PostOnly : the order must add liquidity (maker-only); it is rejected instead of matching immediately
+	PostOnly LimitOrderOption = iota + 1
+	// IOC : immediate-or-cancel, fill whatever is available immediately and cancel the remainder
+	IOC
+	// FOK : fill-or-kill, the order must be filled in full immediately or it is cancelled entirely
+	FOK
+)
+
+// extractLimitOrderOptions pulls any LimitOrderOption values out of a variadic msgs slice so
+// callers can keep writing `e.Buy(stockType, price, amount, api.PostOnly, "some tag")` without
+// a second variadic parameter on Buy/Sell.
+func extractLimitOrderOptions(msgs []interface{}) (opts []LimitOrderOption, rest []interface{}) {
+	for _, msg := range msgs {
+		if opt, ok := msg.(LimitOrderOption); ok {
+			opts = append(opts, opt)
+			continue
+		}
+		rest = append(rest, msg)
+	}
+	return
+}
@@ -0,0 +1,48 @@
+package api
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// rateLimiter is a simple token-bucket limiter: tokens refill continuously at rps per second,
+// up to a capacity of one second's worth of tokens (at least one token), and Wait blocks until
+// one is available.
+type rateLimiter struct {
+	mu       sync.Mutex
+	rps      float64
+	tokens   float64
+	capacity float64
+	last     time.Time
+}
+
+func newRateLimiter(rps float64) *rateLimiter {
+	capacity := math.Max(rps, 1)
+	return &rateLimiter{rps: rps, tokens: capacity, capacity: capacity, last: time.Now()}
+}
+
+// Wait blocks until a token is available, then consumes it. A non-positive rps disables
+// limiting entirely.
+func (l *rateLimiter) Wait() {
+	if l.rps <= 0 {
+		return
+	}
+	for {
+		l.mu.Lock()
+		now := time.Now()
+		l.tokens += now.Sub(l.last).Seconds() * l.rps
+		if l.tokens > l.capacity {
+			l.tokens = l.capacity
+		}
+		l.last = now
+		if l.tokens >= 1 {
+			l.tokens--
+			l.mu.Unlock()
+			return
+		}
+		wait := time.Duration((1 - l.tokens) / l.rps * float64(time.Second))
+		l.mu.Unlock()
+		time.Sleep(wait)
+	}
+}
@@ -0,0 +1,40 @@
+package api
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateLimiterWaitDisabled(t *testing.T) {
+	l := newRateLimiter(0)
+	start := time.Now()
+	for i := 0; i < 1000; i++ {
+		l.Wait()
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("Wait() with non-positive rps took %v, want effectively instant", elapsed)
+	}
+}
+
+func TestRateLimiterWaitConsumesBurst(t *testing.T) {
+	l := newRateLimiter(10)
+	start := time.Now()
+	for i := 0; i < 10; i++ {
+		l.Wait()
+	}
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Errorf("first %d Wait() calls took %v, want them to consume the initial burst instantly", 10, elapsed)
+	}
+}
+
+func TestRateLimiterWaitThrottlesPastBurst(t *testing.T) {
+	l := newRateLimiter(10)
+	for i := 0; i < 10; i++ {
+		l.Wait()
+	}
+	start := time.Now()
+	l.Wait()
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("Wait() past the burst returned after %v, want it to block for roughly 1/rps", elapsed)
+	}
+}
@@ -0,0 +1,70 @@
+package api
+
+import "time"
+
+// OrderEvent : what happened to an order when a TradeRecord was saved, so a resumed session can
+// tell a still-open order apart from one that was already cancelled
+type OrderEvent string
+
+const (
+	// OrderPlaced : the order was just submitted (Buy/Sell)
+	OrderPlaced OrderEvent = "placed"
+	// OrderCancelled : the order was cancelled (CancelOrder)
+	OrderCancelled OrderEvent = "cancelled"
+)
+
+// TradeRecord : a persisted snapshot of one Buy/Sell/CancelOrder result, tagged with the
+// session and exchange it came from so a crashed strategy can resume with knowledge of it
+type TradeRecord struct {
+	SessionID string
+	Exchange  string
+	Time      time.Time
+	Event     OrderEvent
+	Order     Order
+}
+
+// AccountSnapshot : a persisted snapshot of GetAccount's result at a point in time
+type AccountSnapshot struct {
+	SessionID string
+	Exchange  string
+	Time      time.Time
+	Account   Account
+}
+
+// EquityPoint : one point of a rebuilt equity curve
+type EquityPoint struct {
+	Time   time.Time
+	Equity float64
+}
+
+// Store : a pluggable persistence backend for orders, trades and account snapshots, so a
+// strategy can resume after a crash and a dashboard can chart historical equity. QueryTrades
+// and QueryEquityCurve are scoped to a single sessionID/exchange pair so multiple concurrent
+// strategies sharing one Store don't see each other's history.
+type Store interface {
+	SaveOrder(record TradeRecord) error
+	SaveAccount(snapshot AccountSnapshot) error
+	QueryTrades(sessionID, exchange string, since time.Time) ([]TradeRecord, error)
+	QueryEquityCurve(sessionID, exchange string) ([]EquityPoint, error)
+}
+
+// OpenOrdersFromTrades : replay a session's TradeRecord log in order and return the orders that
+// were placed and never cancelled. This is only as good as the log: it cannot tell whether an
+// order has since been filled on the live exchange, so callers should confirm each result with
+// GetOrder before resuming work against it.
+func OpenOrdersFromTrades(records []TradeRecord) []Order {
+	open := make(map[string]Order)
+	for _, record := range records {
+		switch record.Event {
+		case OrderCancelled:
+			delete(open, record.Order.ID)
+		default:
+			open[record.Order.ID] = record.Order
+		}
+	}
+	orders := make([]Order, 0, len(open))
+	for _, order := range open {
+		orders = append(orders, order)
+	}
+	return orders
+}
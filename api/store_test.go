@@ -0,0 +1,25 @@
+package api
+
+import "testing"
+
+func TestOpenOrdersFromTrades(t *testing.T) {
+	records := []TradeRecord{
+		{Event: OrderPlaced, Order: Order{ID: "1"}},
+		{Event: OrderPlaced, Order: Order{ID: "2"}},
+		{Event: OrderCancelled, Order: Order{ID: "1"}},
+	}
+	open := OpenOrdersFromTrades(records)
+	if len(open) != 1 || open[0].ID != "2" {
+		t.Fatalf("OpenOrdersFromTrades = %+v, want only order 2 (order 1 was cancelled)", open)
+	}
+}
+
+func TestOpenOrdersFromTradesPlacedOnly(t *testing.T) {
+	records := []TradeRecord{
+		{Event: OrderPlaced, Order: Order{ID: "1"}},
+	}
+	open := OpenOrdersFromTrades(records)
+	if len(open) != 1 || open[0].ID != "1" {
+		t.Fatalf("OpenOrdersFromTrades = %+v, want order 1 still open", open)
+	}
+}
@@ -0,0 +1,19 @@
+package api
+
+// Trade : a single executed trade reported by an exchange's realtime feed
+type Trade struct {
+	Price     float64
+	Amount    float64
+	Direction string
+	Time      int64
+}
+
+// Stream : a realtime market data feed, as an alternative to polling the HTTP endpoints that
+// GetTicker/GetRecords use. Implementations push typed events onto the returned channel for as
+// long as the feed runs; the interface has no unsubscribe, and the Huobi implementation
+// reconnects after a dropped connection rather than closing the channel.
+type Stream interface {
+	SubscribeDepth(stockType string) (<-chan Ticker, error)
+	SubscribeTrades(stockType string) (<-chan Trade, error)
+	SubscribeKline(stockType, period string) (<-chan Record, error)
+}
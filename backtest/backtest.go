@@ -0,0 +1,260 @@
+// Package backtest implements the GetAccount, Buy, Sell, CancelOrder, GetOrder, GetTicker and
+// GetRecords calls of api.Huobi, but fills orders against a stored history of candlesticks
+// instead of a live exchange, so a strategy can be validated before it is pointed at the real
+// Huobi endpoint. Huobi's other methods (GetOrders, GetTrades, Log, GetMainStock, the stream
+// subscriptions and so on) have no Engine counterpart.
+package backtest
+
+import (
+	"fmt"
+
+	"github.com/miaolz123/samaritan/api"
+)
+
+const (
+	orderTypeBuyLimit   = 1
+	orderTypeSellLimit  = -1
+	orderTypeBuyMarket  = 2
+	orderTypeSellMarket = -2
+)
+
+// Engine : a simulated exchange that walks a fixed set of historical Records chronologically,
+// filling Buy/Sell/CancelOrder calls against them the way Huobi would fill them live
+type Engine struct {
+	stockType    string
+	records      []api.Record
+	cursor       int
+	account      api.Account
+	openOrders   map[string]api.Order
+	nextOrderID  int
+	makerFeeRate float64
+	takerFeeRate float64
+	slippage     float64
+	trades       []Trade
+}
+
+// NewEngine : create a backtest Engine over records, starting from account, charging
+// makerFeeRate/takerFeeRate per fill and simulating slippage (as a fraction of price) on
+// market orders
+func NewEngine(stockType string, records []api.Record, account api.Account, makerFeeRate, takerFeeRate, slippage float64) *Engine {
+	return &Engine{
+		stockType:    stockType,
+		records:      records,
+		account:      account,
+		openOrders:   make(map[string]api.Order),
+		makerFeeRate: makerFeeRate,
+		takerFeeRate: takerFeeRate,
+		slippage:     slippage,
+	}
+}
+
+// GetAccount : get the simulated account's current balances
+func (e *Engine) GetAccount() interface{} {
+	return e.account
+}
+
+// Buy : simulate a buy order against the current bar; a zero/negative price fills immediately
+// at the current close plus slippage, otherwise the order is queued and filled by Step once a
+// bar's Low reaches the limit price, starting with the bar it was placed on
+func (e *Engine) Buy(stockType string, price, amount float64, msgs ...interface{}) (id string) {
+	return e.place(orderTypeBuyLimit, orderTypeBuyMarket, stockType, price, amount)
+}
+
+// Sell : simulate a sell order against the current bar, symmetric to Buy
+func (e *Engine) Sell(stockType string, price, amount float64, msgs ...interface{}) (id string) {
+	return e.place(orderTypeSellLimit, orderTypeSellMarket, stockType, price, amount)
+}
+
+func (e *Engine) place(limitType, marketType int, stockType string, price, amount float64) (id string) {
+	if e.cursor >= len(e.records) {
+		return
+	}
+	bar := e.records[e.cursor]
+	orderType := limitType
+	fillPrice := price
+	if price <= 0 {
+		orderType = marketType
+		fillPrice = bar.Close * (1 + e.slippage*float64(sign(marketType)))
+	}
+	e.nextOrderID++
+	order := api.Order{
+		ID:        fmt.Sprint(e.nextOrderID),
+		Price:     fillPrice,
+		Amount:    amount,
+		OrderType: orderType,
+		StockType: stockType,
+	}
+	if price <= 0 {
+		e.fill(&order, fillPrice, amount, e.takerFeeRate)
+		return order.ID
+	}
+	if !e.reserve(order) {
+		return ""
+	}
+	e.openOrders[order.ID] = order
+	return order.ID
+}
+
+func sign(orderType int) int {
+	if orderType < 0 {
+		return -1
+	}
+	return 1
+}
+
+// reserve moves the balance/stock a queued limit order could consume into
+// FrozenBalance/FrozenStock, so it can no longer be spent by another order, and reports whether
+// the account actually had enough available to reserve.
+func (e *Engine) reserve(order api.Order) bool {
+	fee := order.Price * order.Amount * e.makerFeeRate
+	switch sign(order.OrderType) {
+	case 1:
+		cost := order.Price*order.Amount + fee
+		if e.account.Balance < cost {
+			return false
+		}
+		e.account.Balance -= cost
+		e.account.FrozenBalance += cost
+	case -1:
+		if e.account.Stock < order.Amount {
+			return false
+		}
+		e.account.Stock -= order.Amount
+		e.account.FrozenStock += order.Amount
+	}
+	return true
+}
+
+// unreserve gives back a reservation made by reserve, for an order that is cancelled before it
+// fills.
+func (e *Engine) unreserve(order api.Order) {
+	fee := order.Price * order.Amount * e.makerFeeRate
+	switch sign(order.OrderType) {
+	case 1:
+		cost := order.Price*order.Amount + fee
+		e.account.FrozenBalance -= cost
+		e.account.Balance += cost
+	case -1:
+		e.account.FrozenStock -= order.Amount
+		e.account.Stock += order.Amount
+	}
+}
+
+// CancelOrder : cancel a still-open simulated limit order, releasing its reserved balance/stock
+func (e *Engine) CancelOrder(order api.Order) bool {
+	open, ok := e.openOrders[order.ID]
+	if !ok {
+		return false
+	}
+	e.unreserve(open)
+	delete(e.openOrders, order.ID)
+	return true
+}
+
+// GetOrder : get the current state of a simulated order, open or filled
+func (e *Engine) GetOrder(stockType, id string) interface{} {
+	if order, ok := e.openOrders[id]; ok {
+		return order
+	}
+	for _, trade := range e.trades {
+		if trade.OrderID == id {
+			return api.Order{
+				ID:         id,
+				Price:      trade.Price,
+				Amount:     trade.Amount,
+				DealAmount: trade.Amount,
+				OrderType:  trade.OrderType,
+				StockType:  stockType,
+			}
+		}
+	}
+	return nil
+}
+
+// GetTicker : get a synthetic ticker derived from the current bar's close price
+func (e *Engine) GetTicker(stockType string, sizes ...int) interface{} {
+	if e.cursor >= len(e.records) {
+		return nil
+	}
+	close := e.records[e.cursor].Close
+	return api.Ticker{
+		Buy:  close,
+		Sell: close,
+		Mid:  close,
+		Bids: []api.MarketOrder{{Price: close, Amount: e.account.Stock}},
+		Asks: []api.MarketOrder{{Price: close, Amount: e.account.Stock}},
+	}
+}
+
+// GetRecords : get the records the Engine has walked so far, newest last, matching the live
+// Huobi driver's GetRecords contract
+func (e *Engine) GetRecords(stockType, period string, sizes ...int) []api.Record {
+	end := e.cursor + 1
+	if end > len(e.records) {
+		end = len(e.records)
+	}
+	size := 200
+	if len(sizes) > 0 {
+		size = sizes[0]
+	}
+	start := end - size
+	if start < 0 {
+		start = 0
+	}
+	return e.records[start:end]
+}
+
+// Step : process the current bar's open orders against its High/Low range, then advance to the
+// next bar. Returns false once every record has been walked.
+func (e *Engine) Step() bool {
+	if e.cursor >= len(e.records) {
+		return false
+	}
+	bar := e.records[e.cursor]
+	for id, order := range e.openOrders {
+		if order.OrderType == orderTypeBuyLimit && bar.Low <= order.Price {
+			e.unreserve(order)
+			e.fill(&order, order.Price, order.Amount, e.makerFeeRate)
+			delete(e.openOrders, id)
+		} else if order.OrderType == orderTypeSellLimit && bar.High >= order.Price {
+			e.unreserve(order)
+			e.fill(&order, order.Price, order.Amount, e.makerFeeRate)
+			delete(e.openOrders, id)
+		}
+	}
+	e.cursor++
+	return e.cursor < len(e.records)
+}
+
+// Run : walk every remaining record, invoking strategy once per bar so it can react with
+// Buy/Sell/CancelOrder calls against this Engine, then return the summary Stats
+func (e *Engine) Run(strategy func(e *Engine)) Stats {
+	for e.cursor < len(e.records) {
+		strategy(e)
+		e.Step()
+	}
+	return e.Stats()
+}
+
+func (e *Engine) fill(order *api.Order, price, amount float64, feeRate float64) {
+	fee := price * amount * feeRate
+	switch sign(order.OrderType) {
+	case 1:
+		cost := price*amount + fee
+		e.account.Balance -= cost
+		e.account.Stock += amount
+	case -1:
+		proceeds := price*amount - fee
+		e.account.Balance += proceeds
+		e.account.Stock -= amount
+	}
+	order.DealAmount = amount
+	e.trades = append(e.trades, Trade{
+		OrderID:   order.ID,
+		Time:      e.records[e.cursor].Time,
+		OrderType: order.OrderType,
+		Price:     price,
+		Amount:    amount,
+		Fee:       fee,
+	})
+}
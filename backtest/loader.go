@@ -0,0 +1,63 @@
+package backtest
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"os"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/miaolz123/conver"
+	"github.com/miaolz123/samaritan/api"
+)
+
+// LoadRecordsFromCSV : read historical klines from a CSV file with columns
+// time,open,high,low,close,volume (no header row), in chronological order
+func LoadRecordsFromCSV(path string) (records []api.Record, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	rows, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		return
+	}
+	for _, row := range rows {
+		if len(row) < 6 {
+			return nil, fmt.Errorf("LoadRecordsFromCSV() error, expected 6 columns, got %v", len(row))
+		}
+		records = append(records, api.Record{
+			Time:   conver.Int64Must(row[0]),
+			Open:   conver.Float64Must(row[1]),
+			High:   conver.Float64Must(row[2]),
+			Low:    conver.Float64Must(row[3]),
+			Close:  conver.Float64Must(row[4]),
+			Volume: conver.Float64Must(row[5]),
+		})
+	}
+	return
+}
+
+// LoadRecordsFromSQLite : read historical klines from a SQLite database, running query and
+// expecting each row to return (time, open, high, low, close, volume) in that order
+func LoadRecordsFromSQLite(path, query string) (records []api.Record, err error) {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return
+	}
+	defer db.Close()
+	rows, err := db.Query(query)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var record api.Record
+		if err = rows.Scan(&record.Time, &record.Open, &record.High, &record.Low, &record.Close, &record.Volume); err != nil {
+			return
+		}
+		records = append(records, record)
+	}
+	return records, rows.Err()
+}
@@ -0,0 +1,105 @@
+package backtest
+
+import "math"
+
+// Trade : one simulated fill, recorded so the Engine can report a trade log and summary stats
+type Trade struct {
+	OrderID   string
+	Time      int64
+	OrderType int
+	Price     float64
+	Amount    float64
+	Fee       float64
+}
+
+// Stats : summary performance metrics for a completed backtest run
+type Stats struct {
+	PnL           float64
+	MaxDrawdown   float64
+	SharpeRatio   float64
+	WinRate       float64
+	TotalTrades   int
+	WinningTrades int
+}
+
+// Trades : the full trade log of a completed or in-progress run
+func (e *Engine) Trades() []Trade {
+	return e.trades
+}
+
+// Stats : compute PnL, max drawdown, Sharpe ratio and win rate from the Engine's trade log,
+// matching a round-trip buy followed by a sell as one closed position
+func (e *Engine) Stats() Stats {
+	stats := Stats{}
+	equity := e.account.Balance + e.account.Stock*e.lastPrice()
+	initial := equity
+	peak := equity
+	var returns []float64
+	runningEquity := equity
+	var openPrice float64
+	var openAmount float64
+	closedTrades := 0
+	for _, trade := range e.trades {
+		stats.TotalTrades++
+		prevEquity := runningEquity
+		switch sign(trade.OrderType) {
+		case 1:
+			openPrice = trade.Price
+			openAmount = trade.Amount
+			runningEquity -= trade.Fee
+		case -1:
+			pnl := (trade.Price-openPrice)*math.Min(openAmount, trade.Amount) - trade.Fee
+			runningEquity += pnl
+			closedTrades++
+			if pnl > 0 {
+				stats.WinningTrades++
+			}
+		}
+		if runningEquity > peak {
+			peak = runningEquity
+		}
+		if drawdown := (peak - runningEquity) / peak; peak > 0 && drawdown > stats.MaxDrawdown {
+			stats.MaxDrawdown = drawdown
+		}
+		if prevEquity != 0 {
+			returns = append(returns, (runningEquity-prevEquity)/prevEquity)
+		}
+	}
+	stats.PnL = runningEquity - initial
+	if closedTrades > 0 {
+		stats.WinRate = float64(stats.WinningTrades) / float64(closedTrades)
+	}
+	stats.SharpeRatio = sharpe(returns)
+	return stats
+}
+
+func (e *Engine) lastPrice() float64 {
+	if e.cursor > 0 && e.cursor <= len(e.records) {
+		return e.records[e.cursor-1].Close
+	}
+	if len(e.records) > 0 {
+		return e.records[0].Close
+	}
+	return 0
+}
+
+func sharpe(returns []float64) float64 {
+	if len(returns) < 2 {
+		return 0
+	}
+	mean := 0.0
+	for _, r := range returns {
+		mean += r
+	}
+	mean /= float64(len(returns))
+	variance := 0.0
+	for _, r := range returns {
+		variance += (r - mean) * (r - mean)
+	}
+	variance /= float64(len(returns) - 1)
+	stddev := math.Sqrt(variance)
+	if stddev == 0 {
+		return 0
+	}
+	return mean / stddev * math.Sqrt(float64(len(returns)))
+}
@@ -0,0 +1,74 @@
+package backtest
+
+import (
+	"testing"
+
+	"github.com/miaolz123/samaritan/api"
+)
+
+func TestSharpe(t *testing.T) {
+	if got := sharpe(nil); got != 0 {
+		t.Errorf("sharpe(nil) = %v, want 0", got)
+	}
+	if got := sharpe([]float64{0.01}); got != 0 {
+		t.Errorf("sharpe(single) = %v, want 0", got)
+	}
+	if got := sharpe([]float64{0.01, 0.01, 0.01}); got != 0 {
+		t.Errorf("sharpe(constant positive returns) = %v, want 0 (zero stddev)", got)
+	}
+	if got := sharpe([]float64{0.02, -0.01, 0.03, -0.02}); got <= 0 {
+		t.Errorf("sharpe(mixed positive-mean returns) = %v, want > 0", got)
+	}
+}
+
+func TestEngineStatsRoundTrip(t *testing.T) {
+	records := []api.Record{
+		{Time: 1, Open: 100, High: 110, Low: 90, Close: 100},
+		{Time: 2, Open: 100, High: 120, Low: 95, Close: 110},
+		{Time: 3, Open: 110, High: 130, Low: 100, Close: 120},
+	}
+	e := NewEngine("BTC", records, api.Account{Balance: 1000}, 0, 0, 0)
+
+	e.Buy("BTC", 0, 1) // market buy at bar 0 close (100)
+	e.Step()
+	e.Sell("BTC", 0, 1) // market sell at bar 1 close (110)
+	e.Step()
+
+	stats := e.Stats()
+	if stats.TotalTrades != 2 {
+		t.Fatalf("TotalTrades = %v, want 2", stats.TotalTrades)
+	}
+	if stats.WinningTrades != 1 {
+		t.Fatalf("WinningTrades = %v, want 1", stats.WinningTrades)
+	}
+	if stats.PnL <= 0 {
+		t.Fatalf("PnL = %v, want > 0 for a profitable round trip", stats.PnL)
+	}
+}
+
+func TestEngineReserveRejectsOverBudgetOrders(t *testing.T) {
+	records := []api.Record{
+		{Time: 1, Open: 100, High: 110, Low: 90, Close: 100},
+	}
+	e := NewEngine("BTC", records, api.Account{Balance: 150}, 0, 0, 0)
+
+	first := e.Buy("BTC", 100, 1)
+	if first == "" {
+		t.Fatalf("first buy-limit order should have been accepted")
+	}
+	if e.account.FrozenBalance != 100 {
+		t.Fatalf("FrozenBalance = %v, want 100", e.account.FrozenBalance)
+	}
+
+	second := e.Buy("BTC", 100, 1)
+	if second != "" {
+		t.Fatalf("second buy-limit order should have been rejected for insufficient balance, got id %q", second)
+	}
+
+	if !e.CancelOrder(api.Order{ID: first}) {
+		t.Fatalf("CancelOrder(first) should succeed")
+	}
+	if e.account.FrozenBalance != 0 || e.account.Balance != 150 {
+		t.Fatalf("cancel should fully release the reservation, got Balance=%v FrozenBalance=%v", e.account.Balance, e.account.FrozenBalance)
+	}
+}
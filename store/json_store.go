@@ -0,0 +1,132 @@
+// Package store provides JSON-file, SQLite and Redis implementations of api.Store.
+package store
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/miaolz123/samaritan/api"
+)
+
+// JSONStore : an api.Store backed by a single JSON file, rewriting the whole file to append
+// every order and account snapshot. Intended for single-strategy local use, not concurrent writers.
+type JSONStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+type jsonStoreData struct {
+	Trades   []api.TradeRecord     `json:"trades"`
+	Accounts []api.AccountSnapshot `json:"accounts"`
+}
+
+// NewJSONStore : create a JSONStore persisting to path; the file is created by the first save
+// if it does not exist
+func NewJSONStore(path string) *JSONStore {
+	return &JSONStore{path: path}
+}
+
+func (s *JSONStore) load() (data jsonStoreData, err error) {
+	raw, err := ioutil.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return jsonStoreData{}, nil
+	}
+	if err != nil {
+		return
+	}
+	if len(raw) == 0 {
+		return jsonStoreData{}, nil
+	}
+	err = json.Unmarshal(raw, &data)
+	return
+}
+
+// save writes data to a temp file in the same directory and renames it over s.path, so a crash
+// or power loss mid-write can never leave a half-written, corrupt history file behind.
+func (s *JSONStore) save(data jsonStoreData) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(s.path), filepath.Base(s.path)+".tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	if _, err = tmp.Write(raw); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return os.Rename(tmpPath, s.path)
+}
+
+// SaveOrder : append a TradeRecord to the JSON file
+func (s *JSONStore) SaveOrder(record api.TradeRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, err := s.load()
+	if err != nil {
+		return err
+	}
+	data.Trades = append(data.Trades, record)
+	return s.save(data)
+}
+
+// SaveAccount : append an AccountSnapshot to the JSON file
+func (s *JSONStore) SaveAccount(snapshot api.AccountSnapshot) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, err := s.load()
+	if err != nil {
+		return err
+	}
+	data.Accounts = append(data.Accounts, snapshot)
+	return s.save(data)
+}
+
+// QueryTrades : return every TradeRecord for sessionID/exchange at or after since
+func (s *JSONStore) QueryTrades(sessionID, exchange string, since time.Time) ([]api.TradeRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	trades := []api.TradeRecord{}
+	for _, trade := range data.Trades {
+		if trade.SessionID == sessionID && trade.Exchange == exchange && !trade.Time.Before(since) {
+			trades = append(trades, trade)
+		}
+	}
+	return trades, nil
+}
+
+// QueryEquityCurve : rebuild an equity curve for sessionID/exchange from every persisted
+// AccountSnapshot's Total field, oldest first
+func (s *JSONStore) QueryEquityCurve(sessionID, exchange string) ([]api.EquityPoint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	curve := []api.EquityPoint{}
+	for _, snapshot := range data.Accounts {
+		if snapshot.SessionID == sessionID && snapshot.Exchange == exchange {
+			curve = append(curve, api.EquityPoint{
+				Time:   snapshot.Time,
+				Equity: snapshot.Account.Total,
+			})
+		}
+	}
+	return curve, nil
+}
@@ -0,0 +1,78 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/miaolz123/samaritan/api"
+)
+
+func TestJSONStoreRoundTrip(t *testing.T) {
+	s := NewJSONStore(filepath.Join(t.TempDir(), "store.json"))
+	now := time.Now()
+
+	if err := s.SaveOrder(api.TradeRecord{SessionID: "s1", Exchange: "huobi", Time: now, Event: api.OrderPlaced, Order: api.Order{ID: "1"}}); err != nil {
+		t.Fatalf("SaveOrder() error: %v", err)
+	}
+	if err := s.SaveAccount(api.AccountSnapshot{SessionID: "s1", Exchange: "huobi", Time: now, Account: api.Account{Total: 100}}); err != nil {
+		t.Fatalf("SaveAccount() error: %v", err)
+	}
+
+	trades, err := s.QueryTrades("s1", "huobi", time.Time{})
+	if err != nil {
+		t.Fatalf("QueryTrades() error: %v", err)
+	}
+	if len(trades) != 1 || trades[0].Order.ID != "1" {
+		t.Fatalf("QueryTrades() = %+v, want one trade for order 1", trades)
+	}
+
+	curve, err := s.QueryEquityCurve("s1", "huobi")
+	if err != nil {
+		t.Fatalf("QueryEquityCurve() error: %v", err)
+	}
+	if len(curve) != 1 || curve[0].Equity != 100 {
+		t.Fatalf("QueryEquityCurve() = %+v, want one point with Equity=100", curve)
+	}
+}
+
+func TestJSONStoreSessionIsolation(t *testing.T) {
+	s := NewJSONStore(filepath.Join(t.TempDir(), "store.json"))
+	now := time.Now()
+
+	if err := s.SaveOrder(api.TradeRecord{SessionID: "s1", Exchange: "huobi", Time: now, Order: api.Order{ID: "1"}}); err != nil {
+		t.Fatalf("SaveOrder(s1) error: %v", err)
+	}
+	if err := s.SaveOrder(api.TradeRecord{SessionID: "s2", Exchange: "huobi", Time: now, Order: api.Order{ID: "2"}}); err != nil {
+		t.Fatalf("SaveOrder(s2) error: %v", err)
+	}
+
+	trades, err := s.QueryTrades("s1", "huobi", time.Time{})
+	if err != nil {
+		t.Fatalf("QueryTrades(s1) error: %v", err)
+	}
+	if len(trades) != 1 || trades[0].Order.ID != "1" {
+		t.Fatalf("QueryTrades(s1) = %+v, want only s1's order 1, not s2's history", trades)
+	}
+}
+
+func TestJSONStoreQueryTradesSinceFilter(t *testing.T) {
+	s := NewJSONStore(filepath.Join(t.TempDir(), "store.json"))
+	early := time.Now().Add(-time.Hour)
+	late := time.Now()
+
+	if err := s.SaveOrder(api.TradeRecord{SessionID: "s1", Exchange: "huobi", Time: early, Order: api.Order{ID: "1"}}); err != nil {
+		t.Fatalf("SaveOrder(early) error: %v", err)
+	}
+	if err := s.SaveOrder(api.TradeRecord{SessionID: "s1", Exchange: "huobi", Time: late, Order: api.Order{ID: "2"}}); err != nil {
+		t.Fatalf("SaveOrder(late) error: %v", err)
+	}
+
+	trades, err := s.QueryTrades("s1", "huobi", late.Add(-time.Minute))
+	if err != nil {
+		t.Fatalf("QueryTrades() error: %v", err)
+	}
+	if len(trades) != 1 || trades[0].Order.ID != "2" {
+		t.Fatalf("QueryTrades(since) = %+v, want only the order placed after since", trades)
+	}
+}
@@ -0,0 +1,96 @@
+package store
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+	"github.com/miaolz123/samaritan/api"
+)
+
+const (
+	redisTradesKey   = "samaritan:trades"
+	redisAccountsKey = "samaritan:accounts"
+)
+
+// RedisStore : an api.Store backed by Redis lists, so trade state can be shared across a web
+// dashboard and multiple strategy processes
+type RedisStore struct {
+	pool *redis.Pool
+}
+
+// NewRedisStore : create a RedisStore dialing addr (e.g. "localhost:6379") through a connection
+// pool that keeps up to 8 idle connections for reuse
+func NewRedisStore(addr string) *RedisStore {
+	return &RedisStore{
+		pool: &redis.Pool{
+			MaxIdle: 8,
+			Dial:    func() (redis.Conn, error) { return redis.Dial("tcp", addr) },
+		},
+	}
+}
+
+// SaveOrder : push a TradeRecord onto the trades list
+func (s *RedisStore) SaveOrder(record api.TradeRecord) error {
+	raw, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	conn := s.pool.Get()
+	defer conn.Close()
+	_, err = conn.Do("RPUSH", redisTradesKey, raw)
+	return err
+}
+
+// SaveAccount : push an AccountSnapshot onto the accounts list
+func (s *RedisStore) SaveAccount(snapshot api.AccountSnapshot) error {
+	raw, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+	conn := s.pool.Get()
+	defer conn.Close()
+	_, err = conn.Do("RPUSH", redisAccountsKey, raw)
+	return err
+}
+
+// QueryTrades : return every TradeRecord for sessionID/exchange at or after since
+func (s *RedisStore) QueryTrades(sessionID, exchange string, since time.Time) (trades []api.TradeRecord, err error) {
+	conn := s.pool.Get()
+	defer conn.Close()
+	raws, err := redis.ByteSlices(conn.Do("LRANGE", redisTradesKey, 0, -1))
+	if err != nil {
+		return
+	}
+	for _, raw := range raws {
+		var record api.TradeRecord
+		if err = json.Unmarshal(raw, &record); err != nil {
+			return
+		}
+		if record.SessionID == sessionID && record.Exchange == exchange && !record.Time.Before(since) {
+			trades = append(trades, record)
+		}
+	}
+	return
+}
+
+// QueryEquityCurve : rebuild an equity curve for sessionID/exchange from every persisted
+// account snapshot's Total field, oldest first
+func (s *RedisStore) QueryEquityCurve(sessionID, exchange string) (curve []api.EquityPoint, err error) {
+	conn := s.pool.Get()
+	defer conn.Close()
+	raws, err := redis.ByteSlices(conn.Do("LRANGE", redisAccountsKey, 0, -1))
+	if err != nil {
+		return
+	}
+	for _, raw := range raws {
+		var snapshot api.AccountSnapshot
+		if err = json.Unmarshal(raw, &snapshot); err != nil {
+			return
+		}
+		if snapshot.SessionID == sessionID && snapshot.Exchange == exchange {
+			curve = append(curve, api.EquityPoint{Time: snapshot.Time, Equity: snapshot.Account.Total})
+		}
+	}
+	return
+}
@@ -0,0 +1,110 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/miaolz123/samaritan/api"
+)
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS trades (
+	session_id TEXT, exchange TEXT, time DATETIME, event TEXT, order_json TEXT
+);
+CREATE TABLE IF NOT EXISTS accounts (
+	session_id TEXT, exchange TEXT, time DATETIME, account_json TEXT
+);
+`
+
+// SQLiteStore : an api.Store backed by a SQLite database, one row per persisted order or
+// account snapshot
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteStore : open (creating if needed) the SQLite database at path and ensure its schema
+// exists
+func NewSQLiteStore(path string) (*SQLiteStore, error) {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &SQLiteStore{db: db}, nil
+}
+
+// SaveOrder : insert a TradeRecord row
+func (s *SQLiteStore) SaveOrder(record api.TradeRecord) error {
+	raw, err := json.Marshal(record.Order)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec("INSERT INTO trades (session_id, exchange, time, event, order_json) VALUES (?, ?, ?, ?, ?)",
+		record.SessionID, record.Exchange, record.Time, string(record.Event), string(raw))
+	return err
+}
+
+// SaveAccount : insert an AccountSnapshot row
+func (s *SQLiteStore) SaveAccount(snapshot api.AccountSnapshot) error {
+	raw, err := json.Marshal(snapshot.Account)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec("INSERT INTO accounts (session_id, exchange, time, account_json) VALUES (?, ?, ?, ?)",
+		snapshot.SessionID, snapshot.Exchange, snapshot.Time, string(raw))
+	return err
+}
+
+// QueryTrades : return every TradeRecord for sessionID/exchange at or after since
+func (s *SQLiteStore) QueryTrades(sessionID, exchange string, since time.Time) (trades []api.TradeRecord, err error) {
+	rows, err := s.db.Query(
+		"SELECT session_id, exchange, time, event, order_json FROM trades WHERE session_id = ? AND exchange = ? AND time >= ? ORDER BY time",
+		sessionID, exchange, since)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var record api.TradeRecord
+		var event, orderJSON string
+		if err = rows.Scan(&record.SessionID, &record.Exchange, &record.Time, &event, &orderJSON); err != nil {
+			return
+		}
+		record.Event = api.OrderEvent(event)
+		if err = json.Unmarshal([]byte(orderJSON), &record.Order); err != nil {
+			return
+		}
+		trades = append(trades, record)
+	}
+	return trades, rows.Err()
+}
+
+// QueryEquityCurve : rebuild an equity curve for sessionID/exchange from every persisted
+// account snapshot's Total field, oldest first
+func (s *SQLiteStore) QueryEquityCurve(sessionID, exchange string) (curve []api.EquityPoint, err error) {
+	rows, err := s.db.Query(
+		"SELECT time, account_json FROM accounts WHERE session_id = ? AND exchange = ? ORDER BY time",
+		sessionID, exchange)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var t time.Time
+		var accountJSON string
+		if err = rows.Scan(&t, &accountJSON); err != nil {
+			return
+		}
+		var account api.Account
+		if err = json.Unmarshal([]byte(accountJSON), &account); err != nil {
+			return
+		}
+		curve = append(curve, api.EquityPoint{Time: t, Equity: account.Total})
+	}
+	return curve, rows.Err()
+}